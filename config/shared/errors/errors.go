@@ -0,0 +1,27 @@
+// Copyright 2019 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errors holds the sentinel errors shared between the config
+// packages (schema validation) and the internal packages that act on a
+// parsed config (fetching, compression, etc). Keeping them in one place lets
+// both sides compare against the same values.
+package errors
+
+import "errors"
+
+var (
+	// ErrCompressionInvalid is returned when a resource specifies a
+	// compression algorithm ignition doesn't know how to decode.
+	ErrCompressionInvalid = errors.New("invalid compression method")
+)
@@ -0,0 +1,74 @@
+// Copyright 2020 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package translate
+
+// Registry collects custom translators contributed by code outside a
+// version package - e.g. Butane, or a downstream distro carrying
+// vendor-specific fields - so they run alongside that version's built-in
+// translators without forking it. Each version package (e.g.
+// config/v3_1_experimental/translate) is expected to expose its own
+// package-level Registry and apply it in its Translate function.
+type Registry struct {
+	translators []interface{}
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds fn, which must have the signature func(Old) New, to every
+// Translator built from this Registry via Apply. It is safe to call before
+// the owning version package's Translate has run for the first time.
+func (r *Registry) Register(fn interface{}) {
+	r.translators = append(r.translators, fn)
+}
+
+// Apply registers every translator accumulated in r onto t, in the order
+// they were added, so they take part in t's next Translate call.
+func (r *Registry) Apply(t Translator) {
+	for _, fn := range r.translators {
+		t.AddCustomTranslator(fn)
+	}
+}
+
+// Step translates a config from one spec version to the version
+// immediately above it.
+type Step struct {
+	// Name identifies the spec bump this step performs, e.g. "3.0->3.1".
+	Name string
+	// Translate runs the version package's Translate function, applying
+	// reg's custom translators alongside whichever ones that bump needs
+	// built in, and returns the resulting config and a Report of what
+	// happened.
+	Translate func(old interface{}, reg *Registry) (interface{}, Report)
+}
+
+// Chain runs cfg through each step in order - e.g. 3.0 -> 3.1 -> 3.2 - in a
+// single call, applying reg's custom translators at every step. It returns
+// the final config along with a Report merging every step's, so callers
+// upgrading an old config can see everything that was transformed or
+// dropped across the whole chain, not just the last bump.
+func Chain(cfg interface{}, reg *Registry, steps ...Step) (interface{}, Report) {
+	var report Report
+	cur := cfg
+	for _, step := range steps {
+		next, stepReport := step.Translate(cur, reg)
+		report.Transformed = append(report.Transformed, stepReport.Transformed...)
+		report.Dropped = append(report.Dropped, stepReport.Dropped...)
+		cur = next
+	}
+	return cur, report
+}
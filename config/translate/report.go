@@ -0,0 +1,24 @@
+// Copyright 2020 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package translate
+
+// Report summarizes what a Translate call (or a Chain of them) did to a
+// config: which fields carried over, whether directly or through a custom
+// translator, and which had no equivalent on the destination version and
+// were dropped. Field names are dotted paths, e.g. "Storage.Files".
+type Report struct {
+	Transformed []string
+	Dropped     []string
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package translate implements the field-by-field copying used to upgrade a
+// config from one spec version's types to the next. Each version package
+// (e.g. config/v3_1_experimental/translate) uses a Translator to copy its
+// predecessor's parsed config into its own types, registering a custom
+// translator wherever a field was renamed, restructured, or otherwise can't
+// just be assigned across.
+package translate
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Translator copies an old config struct into a new one, version by
+// version. A value whose type has a registered custom translator is always
+// copied with that translator; otherwise fields present in both structs
+// with the same name and an assignable type are copied directly, and
+// anything else is dropped.
+type Translator struct {
+	translators map[reflect.Type]reflect.Value
+}
+
+// NewTranslator creates a Translator with no custom translators registered.
+func NewTranslator() Translator {
+	return Translator{translators: map[reflect.Type]reflect.Value{}}
+}
+
+// AddCustomTranslator registers fn, which must have the signature
+// func(Old) New, as the translator used whenever Translate encounters a
+// value of type Old, instead of copying it field-by-field.
+func (t Translator) AddCustomTranslator(fn interface{}) {
+	fnType := reflect.TypeOf(fn)
+	if fnType.Kind() != reflect.Func || fnType.NumIn() != 1 || fnType.NumOut() != 1 {
+		panic("AddCustomTranslator must be called with a func(Old) New")
+	}
+	t.translators[fnType.In(0)] = reflect.ValueOf(fn)
+}
+
+// Translate copies old into new, recursing into matching fields and
+// preferring a registered custom translator over a direct field-by-field
+// copy wherever one applies. It returns a Report of which fields were
+// transformed and which had no equivalent on new and so were dropped.
+func (t Translator) Translate(old, new interface{}) Report {
+	var report Report
+	t.translateValue(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), "", &report)
+	return report
+}
+
+func (t Translator) translateValue(oldV, newV reflect.Value, path string, report *Report) {
+	if fn, ok := t.translators[oldV.Type()]; ok {
+		newV.Set(fn.Call([]reflect.Value{oldV})[0])
+		report.Transformed = append(report.Transformed, path)
+		return
+	}
+
+	switch oldV.Kind() {
+	case reflect.Ptr:
+		if oldV.IsNil() {
+			return
+		}
+		if newV.Kind() != reflect.Ptr {
+			t.recordDrop(oldV, path, report)
+			return
+		}
+		newV.Set(reflect.New(newV.Type().Elem()))
+		t.translateValue(oldV.Elem(), newV.Elem(), path, report)
+		return
+
+	case reflect.Struct:
+		for i := 0; i < oldV.NumField(); i++ {
+			name := oldV.Type().Field(i).Name
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			newField := newV.FieldByName(name)
+			if !newField.IsValid() {
+				t.recordDrop(oldV.Field(i), fieldPath, report)
+				continue
+			}
+			t.translateValue(oldV.Field(i), newField, fieldPath, report)
+		}
+		return
+
+	case reflect.Slice:
+		if oldV.IsNil() {
+			return
+		}
+		if newV.Kind() != reflect.Slice {
+			t.recordDrop(oldV, path, report)
+			return
+		}
+		newV.Set(reflect.MakeSlice(newV.Type(), oldV.Len(), oldV.Len()))
+		for i := 0; i < oldV.Len(); i++ {
+			t.translateValue(oldV.Index(i), newV.Index(i), fmt.Sprintf("%s[%d]", path, i), report)
+		}
+		return
+
+	case reflect.Map:
+		if oldV.IsNil() {
+			return
+		}
+		if newV.Kind() != reflect.Map {
+			t.recordDrop(oldV, path, report)
+			return
+		}
+		newV.Set(reflect.MakeMapWithSize(newV.Type(), oldV.Len()))
+		iter := oldV.MapRange()
+		for iter.Next() {
+			newElem := reflect.New(newV.Type().Elem()).Elem()
+			t.translateValue(iter.Value(), newElem, fmt.Sprintf("%s[%v]", path, iter.Key()), report)
+			newV.SetMapIndex(iter.Key(), newElem)
+		}
+		return
+	}
+
+	if oldV.Type().AssignableTo(newV.Type()) {
+		newV.Set(oldV)
+		report.Transformed = append(report.Transformed, path)
+		return
+	}
+	t.recordDrop(oldV, path, report)
+}
+
+// recordDrop flags path as dropped, unless oldV holds its type's zero value.
+// An unset field has nothing to lose by having no destination on the other
+// side, so it shouldn't show up as a meaningful change in the Report -
+// otherwise Translate would flag essentially every field the destination
+// version hasn't caught up to yet, whether or not a given config uses it.
+func (t Translator) recordDrop(oldV reflect.Value, path string, report *Report) {
+	if !oldV.IsZero() {
+		report.Dropped = append(report.Dropped, path)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package translate
+
+import (
+	"reflect"
+	"testing"
+)
+
+type v1Config struct {
+	Version string
+	Files   []v1File
+	Note    *string
+}
+
+type v1File struct {
+	Path string
+	Mode int
+}
+
+type v2Config struct {
+	Version string
+	Files   []v2File
+	Note    *string
+}
+
+type v2File struct {
+	Path string
+	Mode string
+}
+
+func translateMode(old int) string {
+	if old == 0 {
+		return ""
+	}
+	return "mode-" + string(rune('0'+old))
+}
+
+func TestTranslateSliceAndPointer(t *testing.T) {
+	note := "hello"
+	old := v1Config{
+		Version: "1.0",
+		Files:   []v1File{{Path: "/a", Mode: 1}, {Path: "/b", Mode: 2}},
+		Note:    &note,
+	}
+
+	tr := NewTranslator()
+	tr.AddCustomTranslator(translateMode)
+	var new v2Config
+	tr.Translate(&old, &new)
+
+	want := v2Config{
+		Version: "1.0",
+		Files:   []v2File{{Path: "/a", Mode: "mode-1"}, {Path: "/b", Mode: "mode-2"}},
+		Note:    &note,
+	}
+	if !reflect.DeepEqual(want, new) {
+		t.Errorf("bad translation: want %+v, got %+v", want, new)
+	}
+}
+
+func TestRegistryAppliesCustomTranslator(t *testing.T) {
+	old := v1Config{Files: []v1File{{Path: "/a", Mode: 5}}}
+
+	reg := NewRegistry()
+	reg.Register(translateMode)
+
+	tr := NewTranslator()
+	reg.Apply(tr)
+
+	var new v2Config
+	tr.Translate(&old, &new)
+
+	want := "mode-5"
+	if new.Files[0].Mode != want {
+		t.Errorf("registered translator didn't fire: want %q, got %q", want, new.Files[0].Mode)
+	}
+}
+
+func TestChain(t *testing.T) {
+	type v3Config struct {
+		Version string
+		Files   []v2File
+	}
+
+	reg := NewRegistry()
+	reg.Register(translateMode)
+
+	steps := []Step{
+		{
+			Name: "v1->v2",
+			Translate: func(old interface{}, reg *Registry) (interface{}, Report) {
+				tr := NewTranslator()
+				reg.Apply(tr)
+				var new v2Config
+				report := tr.Translate(old.(*v1Config), &new)
+				return &new, report
+			},
+		},
+		{
+			Name: "v2->v3",
+			Translate: func(old interface{}, reg *Registry) (interface{}, Report) {
+				tr := NewTranslator()
+				var new v3Config
+				report := tr.Translate(old.(*v2Config), &new)
+				return &new, report
+			},
+		},
+	}
+
+	old := &v1Config{Version: "1.0", Files: []v1File{{Path: "/a", Mode: 1}}}
+	result, report := Chain(old, reg, steps...)
+
+	final := result.(*v3Config)
+	want := v3Config{Version: "1.0", Files: []v2File{{Path: "/a", Mode: "mode-1"}}}
+	if !reflect.DeepEqual(want, *final) {
+		t.Errorf("bad chained translation: want %+v, got %+v", want, *final)
+	}
+	if len(report.Transformed) == 0 {
+		t.Errorf("expected Chain's merged report to record transformed fields")
+	}
+}
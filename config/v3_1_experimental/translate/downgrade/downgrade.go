@@ -0,0 +1,59 @@
+// Copyright 2020 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package downgrade translates a v3.1 config back into the v3.0 config it
+// could have been upgraded from - the reverse of translate.Translate -
+// failing rather than silently dropping fields v3.0 has no equivalent for.
+package downgrade
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coreos/ignition/config/translate"
+	old_types "github.com/coreos/ignition/config/v3_0/types"
+	"github.com/coreos/ignition/config/v3_1_experimental/types"
+)
+
+// ErrUnsupportedFields is returned by Translate when cfg uses one or more
+// fields that have no v3.0 equivalent, so it can't be downgraded without
+// losing information.
+type ErrUnsupportedFields struct {
+	Fields []string
+}
+
+func (e ErrUnsupportedFields) Error() string {
+	return fmt.Sprintf("config uses fields with no v3.0 equivalent: %s", strings.Join(e.Fields, ", "))
+}
+
+// Translate walks cfg, a v3.1 config, back into the v3.0 types it was
+// originally translated from. It returns ErrUnsupportedFields if cfg uses
+// any field v3.0 has no equivalent for, rather than silently dropping it.
+//
+// Unlike the forward translation, this deliberately has no custom
+// translator for the Ignition subtree: a custom translator's report is its
+// own, and swallowing it here would let a v3.1-only field nested under
+// Ignition (e.g. ignition.proxy) vanish without ever reaching
+// ErrUnsupportedFields. Translating Ignition field-by-field instead lets
+// every drop anywhere in the config surface in the same report.
+func Translate(cfg types.Config) (old_types.Config, error) {
+	var ret old_types.Config
+	tr := translate.NewTranslator()
+	report := tr.Translate(&cfg, &ret)
+	ret.Ignition.Version = old_types.MaxVersion.String()
+	if len(report.Dropped) > 0 {
+		return old_types.Config{}, ErrUnsupportedFields{Fields: report.Dropped}
+	}
+	return ret, nil
+}
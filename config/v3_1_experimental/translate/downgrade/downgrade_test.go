@@ -0,0 +1,99 @@
+// Copyright 2020 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package downgrade
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	old_types "github.com/coreos/ignition/config/v3_0/types"
+	"github.com/coreos/ignition/config/v3_1_experimental/types"
+)
+
+func TestTranslateRoundTrip(t *testing.T) {
+	var cfg types.Config
+
+	old, err := Translate(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(old_types.Config{}, old) {
+		t.Errorf("expected a config using only shared fields to round-trip unchanged, got %+v", old)
+	}
+}
+
+func TestTranslateRoundTripPopulated(t *testing.T) {
+	cfg := types.Config{
+		Storage: types.Storage{
+			Files: []types.File{
+				{Path: "/foo"},
+				{Path: "/bar"},
+			},
+		},
+	}
+
+	old, err := Translate(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error translating a config that only uses fields shared with v3.0: %v", err)
+	}
+
+	want := old_types.Config{
+		Storage: old_types.Storage{
+			Files: []old_types.File{
+				{Path: "/foo"},
+				{Path: "/bar"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(want, old) {
+		t.Errorf("populated shared-only config did not round-trip: want %+v, got %+v", want, old)
+	}
+}
+
+func TestErrUnsupportedFieldsMessage(t *testing.T) {
+	err := ErrUnsupportedFields{Fields: []string{"Storage.Luks"}}
+	want := "config uses fields with no v3.0 equivalent: Storage.Luks"
+	if err.Error() != want {
+		t.Errorf("bad error message: got %q, want %q", err.Error(), want)
+	}
+}
+
+// TestTranslateRejectsV31OnlyField ensures a field nested under Ignition
+// that v3.0 has no equivalent for - e.g. ignition.proxy, added in 3.1 - is
+// caught and reported rather than silently dropped.
+func TestTranslateRejectsV31OnlyField(t *testing.T) {
+	httpProxy := "http://proxy.example.com:80"
+	cfg := types.Config{
+		Ignition: types.Ignition{
+			Proxy: types.Proxy{
+				HTTPProxy: &httpProxy,
+			},
+		},
+	}
+
+	_, err := Translate(cfg)
+	unsupported, ok := err.(ErrUnsupportedFields)
+	if !ok {
+		t.Fatalf("expected ErrUnsupportedFields, got %v", err)
+	}
+
+	for _, field := range unsupported.Fields {
+		if strings.HasPrefix(field, "Ignition.Proxy") {
+			return
+		}
+	}
+	t.Errorf("expected Ignition.Proxy to be reported as unsupported, got %v", unsupported.Fields)
+}
@@ -27,10 +27,25 @@ func translateIgnition(old old_types.Ignition) (ret types.Ignition) {
 	return
 }
 
+// CustomTranslators lets code outside this package - e.g. Butane, or a
+// downstream distro carrying vendor-specific fields - register additional
+// custom translators to run during Translate, without forking this package.
+// Register against it before calling Translate.
+var CustomTranslators = translate.NewRegistry()
 
 func Translate(old old_types.Config) (ret types.Config) {
+	ret, _ = TranslateWithReport(old)
+	return
+}
+
+// TranslateWithReport behaves like Translate, but also returns a Report of
+// which fields were transformed and which had no 3.1 equivalent and were
+// dropped, so callers upgrading an old config can see exactly what changed.
+func TranslateWithReport(old old_types.Config) (types.Config, translate.Report) {
+	var ret types.Config
 	tr := translate.NewTranslator()
 	tr.AddCustomTranslator(translateIgnition)
-	tr.Translate(&old, &ret)
-	return
+	CustomTranslators.Apply(tr)
+	report := tr.Translate(&old, &ret)
+	return ret, report
 }
@@ -0,0 +1,62 @@
+// Copyright 2015 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package log provides the leveled logger used throughout ignition. It is
+// intentionally tiny: a thin wrapper around the standard logger whose debug
+// output can be switched off, and that callers pass around by pointer so every
+// component shares the same destination.
+package log
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// Logger is the leveled logger passed to every component that needs to emit
+// diagnostics during provisioning.
+type Logger struct {
+	verbose bool
+	logger  *log.Logger
+}
+
+// New creates a new Logger. When verbose is false, Debug messages are
+// discarded.
+func New(verbose bool) Logger {
+	return Logger{
+		verbose: verbose,
+		logger:  log.New(os.Stderr, "ignition: ", log.Lshortfile),
+	}
+}
+
+// Close releases any resources held by the logger.
+func (l *Logger) Close() {}
+
+// Debug logs a debug-level message. It is a no-op unless the logger was
+// created with verbose logging enabled.
+func (l *Logger) Debug(format string, a ...interface{}) {
+	if l.verbose {
+		l.logger.Output(2, fmt.Sprintf("DEBUG "+format, a...))
+	}
+}
+
+// Info logs an info-level message.
+func (l *Logger) Info(format string, a ...interface{}) {
+	l.logger.Output(2, fmt.Sprintf("INFO "+format, a...))
+}
+
+// Err logs an error-level message.
+func (l *Logger) Err(format string, a ...interface{}) {
+	l.logger.Output(2, fmt.Sprintf("ERROR "+format, a...))
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// fetchFromHTTP retrieves u over HTTP(S) and writes the decompressed,
+// verified result to dest. If opts.CacheDir is set, the raw fetch is first
+// written into a cache file there; an interrupted attempt resumes with a
+// Range request the next time it's fetched instead of starting over.
+func (f *Fetcher) fetchFromHTTP(u url.URL, dest io.Writer, opts FetchOptions) error {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if opts.CacheDir == "" {
+		resp, err := f.httpGet(client, u, opts.Headers, 0)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		return decompressAndVerify(resp.Body, dest, opts)
+	}
+
+	cache, err := openResumableCache(opts.CacheDir, cacheKeyForURL(u))
+	if err != nil {
+		return err
+	}
+
+	if err := f.fetchIntoCache(client, u, opts, cache); err != nil {
+		cache.close(cacheKeep)
+		return err
+	}
+
+	if _, err := cache.dest.Seek(0, io.SeekStart); err != nil {
+		cache.close(cacheKeep)
+		return err
+	}
+
+	if err := decompressAndVerify(cache.dest, dest, opts); err != nil {
+		// What's cached either doesn't match the configured digest or
+		// doesn't decompress; either way it must not be resumed from again.
+		cache.close(cacheInvalid)
+		return err
+	}
+
+	return cache.close(cacheDone)
+}
+
+// fetchIntoCache sends a GET for u, resuming at cache.offset via a Range
+// header, and appends the response body into cache. If the server doesn't
+// honor the Range request (it replies 200 instead of 206), the cache is
+// reset and the full body is written in its place. If the server reports
+// our offset is no longer satisfiable (416, e.g. the resource shrank or
+// changed since we last fetched it), the cache is reset and the resource is
+// fetched again from the start.
+func (f *Fetcher) fetchIntoCache(client *http.Client, u url.URL, opts FetchOptions, cache *resumableCache) error {
+	resp, err := f.httpGet(client, u, opts.Headers, cache.offset)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		resp.Body.Close()
+		if err := cache.reset(); err != nil {
+			return err
+		}
+		resp, err = f.httpGet(client, u, opts.Headers, 0)
+		if err != nil {
+			return err
+		}
+	} else if cache.offset > 0 && resp.StatusCode != http.StatusPartialContent {
+		if err := cache.reset(); err != nil {
+			resp.Body.Close()
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(cache, resp.Body); err != nil {
+		return err
+	}
+	return cache.persist()
+}
+
+// httpGet issues a GET for u, adding a Range header requesting everything
+// from offset onward when offset is positive. A 416 response is returned to
+// the caller rather than treated as an error, so a resumed fetch whose
+// offset the server can no longer satisfy can restart from scratch instead
+// of failing outright.
+func (f *Fetcher) httpGet(client *http.Client, u url.URL, headers http.Header, offset int64) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable:
+		return resp, nil
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected HTTP status fetching %s: %s", u.String(), resp.Status)
+	}
+}
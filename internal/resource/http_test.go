@@ -0,0 +1,166 @@
+// Copyright 2020 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/ignition/v2/internal/log"
+)
+
+const fullBody = "the quick brown fox jumps over the lazy dog"
+
+// seedCache writes partial content into dir's cache file for u and persists
+// the matching state, as if a previous fetch had been interrupted after
+// writing that much.
+func seedCache(t *testing.T, dir string, u url.URL, partial string) {
+	t.Helper()
+	cache, err := openResumableCache(dir, cacheKeyForURL(u))
+	if err != nil {
+		t.Fatalf("seeding cache: %v", err)
+	}
+	if _, err := cache.Write([]byte(partial)); err != nil {
+		t.Fatalf("seeding cache: %v", err)
+	}
+	if err := cache.close(cacheKeep); err != nil {
+		t.Fatalf("seeding cache: %v", err)
+	}
+}
+
+func newTestFetcher() Fetcher {
+	logger := log.New(false)
+	return Fetcher{Logger: &logger}
+}
+
+func assertCacheGone(t *testing.T, dir string, u url.URL) {
+	t.Helper()
+	destPath := filepath.Join(dir, cacheKeyForURL(u))
+	if _, err := os.Stat(destPath); !os.IsNotExist(err) {
+		t.Errorf("expected cache file to be removed after a successful fetch, stat err: %v", err)
+	}
+	if _, err := os.Stat(destPath + ".state"); !os.IsNotExist(err) {
+		t.Errorf("expected cache state to be removed after a successful fetch, stat err: %v", err)
+	}
+}
+
+// TestFetchFromHTTPResumes206 covers a server that honors the Range request
+// made for a partially cached resource, appending only the missing bytes.
+func TestFetchFromHTTPResumes206(t *testing.T) {
+	dir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rng := r.Header.Get("Range")
+		if rng == "" {
+			t.Errorf("expected a Range request, got none")
+		}
+		var offset int
+		if _, err := fmt.Sscanf(rng, "bytes=%d-", &offset); err != nil {
+			t.Fatalf("bad Range header %q: %v", rng, err)
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(fullBody)-1, len(fullBody)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte(fullBody[offset:]))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %v", err)
+	}
+
+	seedCache(t, dir, *u, fullBody[:10])
+
+	f := newTestFetcher()
+	var dest bytes.Buffer
+	if err := f.fetchFromHTTP(*u, &dest, FetchOptions{CacheDir: dir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dest.String() != fullBody {
+		t.Errorf("bad content: want %q, got %q", fullBody, dest.String())
+	}
+	assertCacheGone(t, dir, *u)
+}
+
+// TestFetchFromHTTPFallsBackOn200 covers a server that doesn't honor Range
+// requests and always sends the whole resource; the cache must be reset
+// rather than have the full body appended after the stale partial bytes.
+func TestFetchFromHTTPFallsBackOn200(t *testing.T) {
+	dir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fullBody))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %v", err)
+	}
+
+	seedCache(t, dir, *u, fullBody[:10])
+
+	f := newTestFetcher()
+	var dest bytes.Buffer
+	if err := f.fetchFromHTTP(*u, &dest, FetchOptions{CacheDir: dir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dest.String() != fullBody {
+		t.Errorf("bad content: want %q, got %q", fullBody, dest.String())
+	}
+	assertCacheGone(t, dir, *u)
+}
+
+// TestFetchFromHTTPRefetchesOn416 covers a server that reports our cached
+// offset is no longer satisfiable; the cache must be reset and the resource
+// refetched from the start rather than treated as a hard error.
+func TestFetchFromHTTPRefetchesOn416(t *testing.T) {
+	dir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fullBody))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("parsing server URL: %v", err)
+	}
+
+	// Simulate a cached offset the resource shrank past.
+	seedCache(t, dir, *u, fullBody+" and then some")
+
+	f := newTestFetcher()
+	var dest bytes.Buffer
+	if err := f.fetchFromHTTP(*u, &dest, FetchOptions{CacheDir: dir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dest.String() != fullBody {
+		t.Errorf("bad content: want %q, got %q", fullBody, dest.String())
+	}
+	assertCacheGone(t, dir, *u)
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// resumableState is the bookkeeping persisted alongside a partially fetched
+// resource so a later attempt can pick up where this one left off. The
+// fetched bytes are verified against the digests in FetchOptions only once
+// the whole resource is assembled, so there's nothing for the cache itself
+// to authenticate in the meantime - it just needs to know how much it has.
+type resumableState struct {
+	Offset int64 `json:"offset"`
+}
+
+// resumableCache is a destination file living under a FetchOptions.CacheDir,
+// along with the offset a previous, interrupted fetch left off at.
+type resumableCache struct {
+	dest      *os.File
+	statePath string
+	offset    int64
+}
+
+// cacheResult tells resumableCache.close what to do with the cache file and
+// its state sidecar once a fetch attempt is done with them.
+type cacheResult int
+
+const (
+	// cacheKeep persists the current offset so the next attempt can resume
+	// from here, e.g. after a network error.
+	cacheKeep cacheResult = iota
+	// cacheDone removes the cache entirely: the resource was fetched and
+	// verified successfully, so there's nothing left to resume.
+	cacheDone
+	// cacheInvalid removes the cache entirely: what was cached didn't
+	// verify (the digest didn't match, or the upstream content changed),
+	// so it must not be resumed from again.
+	cacheInvalid
+)
+
+// openResumableCache opens (creating if necessary) the cache file for key
+// within dir, returning it positioned at the end of whatever was previously
+// downloaded. If the persisted offset can't be trusted - there is none, or
+// it's past the end of what's actually on disk - the cache is reset rather
+// than resumed from.
+func openResumableCache(dir, key string) (*resumableCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	destPath := filepath.Join(dir, key)
+	f, err := os.OpenFile(destPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &resumableCache{dest: f, statePath: destPath + ".state"}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if info.Size() > 0 {
+		if !rc.restoreState() {
+			if err := rc.reset(); err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := f.Seek(rc.offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return rc, nil
+}
+
+// restoreState attempts to pick up a previous attempt's offset from the
+// state sidecar, returning whether it succeeded.
+func (rc *resumableCache) restoreState() bool {
+	state, err := readResumableState(rc.statePath)
+	if err != nil {
+		return false
+	}
+
+	info, err := rc.dest.Stat()
+	if err != nil || state.Offset > info.Size() {
+		return false
+	}
+
+	rc.offset = state.Offset
+	return true
+}
+
+// cacheKeyForURL derives a stable cache file name from a URL.
+func cacheKeyForURL(u url.URL) string {
+	sum := sha256.Sum256([]byte(u.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func readResumableState(path string) (resumableState, error) {
+	var state resumableState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+// Write appends p to the cache file.
+func (rc *resumableCache) Write(p []byte) (int, error) {
+	return rc.dest.Write(p)
+}
+
+// persist writes the cache's current offset to its sidecar state file, so a
+// later attempt can resume without rereading the whole cache file.
+func (rc *resumableCache) persist() error {
+	info, err := rc.dest.Stat()
+	if err != nil {
+		return err
+	}
+	rc.offset = info.Size()
+
+	data, err := json.Marshal(resumableState{Offset: rc.offset})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(rc.statePath, data, 0644)
+}
+
+// reset discards whatever was previously cached, e.g. because the server
+// didn't honor our Range request and sent the whole resource again, or
+// because a 416 told us the resource is now shorter than our offset.
+func (rc *resumableCache) reset() error {
+	if err := rc.dest.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := rc.dest.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	rc.offset = 0
+	return rc.persist()
+}
+
+// close releases the cache's underlying file. cacheDone and cacheInvalid
+// both remove the cache file and its state sidecar - the former because
+// there's nothing left to resume, the latter because what's cached can't be
+// trusted and must not be resumed from again. cacheKeep just persists the
+// current offset for next time.
+func (rc *resumableCache) close(result cacheResult) error {
+	defer rc.dest.Close()
+	switch result {
+	case cacheDone, cacheInvalid:
+		os.Remove(rc.dest.Name())
+		os.Remove(rc.statePath)
+		return nil
+	default:
+		return rc.persist()
+	}
+}
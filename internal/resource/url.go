@@ -0,0 +1,218 @@
+// Copyright 2015 CoreOS, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resource implements fetching of remote and local resources
+// (data URLs, HTTP(S), TFTP, S3, GCS, ...) referenced by a config, with a
+// shared set of options for decompression and digest verification.
+package resource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"github.com/coreos/ignition/v2/config/shared/errors"
+	"github.com/coreos/ignition/v2/config/v3_1_experimental/types"
+	"github.com/coreos/ignition/v2/internal/log"
+	"github.com/coreos/ignition/v2/internal/util"
+)
+
+// FetchOptions controls how a resource is retrieved and validated once it
+// has been retrieved. It is shared across every fetch backend (data URL,
+// HTTP(S), TFTP, S3, GCS, ...) so they all decompress and verify identically.
+type FetchOptions struct {
+	// Hash and ExpectedSum pin the resource with a single, unnamed digest.
+	// Kept for backwards compatibility; new callers should use Hashes.
+	Hash        hash.Hash
+	ExpectedSum []byte
+
+	// Hashes pins the resource with one or more named digests. Every entry
+	// must verify for the fetch to succeed.
+	Hashes []util.ExpectedHash
+
+	// Compression names the compression the fetched bytes are wrapped in,
+	// or "" if they aren't compressed at all.
+	Compression string
+
+	// Headers are added to any outgoing HTTP(S) request.
+	Headers http.Header
+
+	// CacheDir, if set, is a directory HTTP(S) fetches may use to persist a
+	// partial download so an interrupted fetch can be resumed with a Range
+	// request instead of starting over.
+	CacheDir string
+}
+
+// Fetcher retrieves resources referenced by a config.
+type Fetcher struct {
+	Logger *log.Logger
+	Client *http.Client
+}
+
+// fetchFromDataURL decodes a data: URL per RFC 2397, decompresses it if
+// opts.Compression is set, and writes the result to dest, verifying every
+// digest configured in opts along the way.
+func (f *Fetcher) fetchFromDataURL(u url.URL, dest io.Writer, opts FetchOptions) error {
+	data, err := decodeDataURL(u)
+	if err != nil {
+		return err
+	}
+	return decompressAndVerify(bytes.NewReader(data), dest, opts)
+}
+
+// decompressAndVerify decompresses src according to opts.Compression and
+// copies it to dest, verifying every digest configured in opts along the
+// way. It is the shared tail end of every fetch backend (data URL, HTTP(S),
+// TFTP, S3, GCS, ...), so they all decompress and verify identically.
+func decompressAndVerify(src io.Reader, dest io.Writer, opts FetchOptions) error {
+	reader, err := newDecompressingReader(src, opts.Compression)
+	if err != nil {
+		return err
+	}
+	if c, ok := reader.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	w, verify, err := newVerifyingWriter(dest, opts)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, reader); err != nil {
+		return err
+	}
+
+	return verify()
+}
+
+// decodeDataURL extracts the payload of a data: URL, base64-decoding it if
+// the URL declares that encoding.
+func decodeDataURL(u url.URL) ([]byte, error) {
+	body := u.Opaque
+	if body == "" {
+		body = u.Path
+	}
+
+	idx := strings.Index(body, ",")
+	if idx < 0 {
+		return nil, fmt.Errorf("malformed data URL: missing comma")
+	}
+	meta, encoded := body[:idx], body[idx+1:]
+
+	if strings.HasSuffix(meta, ";base64") {
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+
+	decoded, err := url.QueryUnescape(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(decoded), nil
+}
+
+// newDecompressingReader wraps src so reads from it are transparently
+// decompressed according to compression. It is the single dispatch point
+// used by every fetch backend (data URL, HTTP(S), TFTP, S3, GCS, ...), so
+// they all support the same set of compression algorithms and none of them
+// need to buffer the whole payload just to decompress it. compression is
+// validated through types.Compression, the same validation a config goes
+// through, so the fetch path can never drift out of sync with what's
+// actually accepted at parse time.
+func newDecompressingReader(src io.Reader, compression string) (io.Reader, error) {
+	c := types.Compression(compression)
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch c {
+	case types.CompressionNone:
+		return src, nil
+	case types.CompressionGzip:
+		return gzip.NewReader(src)
+	case types.CompressionZstd:
+		zr, err := zstd.NewReader(src)
+		if err != nil {
+			return nil, err
+		}
+		return &zstdReadCloser{zr}, nil
+	case types.CompressionXz:
+		return xz.NewReader(src)
+	default:
+		return nil, errors.ErrCompressionInvalid
+	}
+}
+
+// zstdReadCloser adapts *zstd.Decoder's Close (which returns nothing) to
+// io.Closer, so it can be deferred alongside the other decompressors.
+type zstdReadCloser struct {
+	*zstd.Decoder
+}
+
+func (z *zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return nil
+}
+
+// newVerifyingWriter wraps dest so that bytes written through the returned
+// writer are also fed to every digest configured in opts. The returned
+// function must be called once all bytes have been written; it checks every
+// configured digest and returns the first mismatch found.
+func newVerifyingWriter(dest io.Writer, opts FetchOptions) (io.Writer, func() error, error) {
+	writers := []io.Writer{dest}
+	var checks []func() error
+
+	if opts.Hash != nil {
+		opts.Hash.Reset()
+		writers = append(writers, opts.Hash)
+		checks = append(checks, func() error {
+			calculated := opts.Hash.Sum(nil)
+			if !bytes.Equal(calculated, opts.ExpectedSum) {
+				return util.ErrHashMismatch{
+					Calculated: hex.EncodeToString(calculated),
+					Expected:   hex.EncodeToString(opts.ExpectedSum),
+				}
+			}
+			return nil
+		})
+	}
+
+	if len(opts.Hashes) > 0 {
+		mh, err := util.NewMultiHash(opts.Hashes)
+		if err != nil {
+			return nil, nil, err
+		}
+		writers = append(writers, mh)
+		checks = append(checks, mh.Verify)
+	}
+
+	verify := func() error {
+		for _, check := range checks {
+			if err := check(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return io.MultiWriter(writers...), verify, nil
+}
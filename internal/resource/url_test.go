@@ -89,6 +89,47 @@ SELINUX=permissive`
 			},
 			out: out{},
 		},
+		{
+			in: in{
+				URL: url.URL{
+					Scheme: "data",
+					Opaque: ";base64,KLUv/SQgAQEAIyBUaGlzIGZpbGUKClNFTElOVVg9cGVybWlzc2l2ZQphYBwy",
+				},
+				dest: bytes.Buffer{},
+				opts: FetchOptions{
+					Compression: "zstd",
+				},
+			},
+			out: out{},
+		},
+		{
+			in: in{
+				URL: url.URL{
+					Scheme: "data",
+					Opaque: ";base64,/Td6WFoAAATm1rRGBMAkICEBFgAAAAAAAAAAANqJ6rMBAB8jIFRoaXMgZmlsZQoKU0VMSU5VWD1wZXJtaXNzaXZlCgD/j9ItHUVv6AABQCDm2pHrH7bzfQEAAAAABFla",
+				},
+				dest: bytes.Buffer{},
+				opts: FetchOptions{
+					Compression: "xz",
+				},
+			},
+			out: out{},
+		},
+		{
+			in: in{
+				URL: url.URL{
+					Scheme: "data",
+					Opaque: ";base64,KLUv/SQgAQEAIyBUaGlzIGZpbGUKClNFTElOVVg9cGVybWlzc2l2ZQphYBwy",
+				},
+				dest: bytes.Buffer{},
+				opts: FetchOptions{
+					Compression: "zstd",
+					Hash:        sha512.New(),
+					ExpectedSum: decodeString("58b63cd659fbf2264a4d59e6061bff888c54d0d98dc27a3167a607ce92076e906352f73bce72d563d66dd5322496c7f9542c0d22bb23955d17e4a71784d1155f"),
+				},
+			},
+			out: out{},
+		},
 		{
 			in: in{
 				URL: url.URL{
@@ -184,6 +225,43 @@ SELINUX=permissive`
 				Expected:   "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043",
 			}},
 		},
+		{
+			in: in{
+				URL: url.URL{
+					Scheme: "data",
+					Opaque: ";base64,IyBUaGlzIGZpbGUKClNFTElOVVg9cGVybWlzc2l2ZQo=",
+				},
+				dest: bytes.Buffer{},
+				opts: FetchOptions{
+					Hashes: []util.ExpectedHash{
+						{Algorithm: util.HashAlgorithmSHA256, Sum: decodeString("ca94eb1e9d37b8566b45e7c0bd91ebf320fbea7729ae1f2f3f2d73a9f481aa03")},
+						{Algorithm: util.HashAlgorithmSHA512, Sum: decodeString("58b63cd659fbf2264a4d59e6061bff888c54d0d98dc27a3167a607ce92076e906352f73bce72d563d66dd5322496c7f9542c0d22bb23955d17e4a71784d1155f")},
+						{Algorithm: util.HashAlgorithmBlake2b512, Sum: decodeString("5b766240233a4fe0674021afd39e9b1f40f91d008419fcf72dc1a8f23b92383dee1ade8485058a60a455bff43df7df08f61ef7449311caeb618507246158c85c")},
+					},
+				},
+			},
+			out: out{},
+		},
+		{
+			in: in{
+				URL: url.URL{
+					Scheme: "data",
+					Opaque: ";base64,IyBUaGlzIGZpbGUKClNFTElOVVg9cGVybWlzc2l2ZQo=",
+				},
+				dest: bytes.Buffer{},
+				opts: FetchOptions{
+					Hashes: []util.ExpectedHash{
+						{Algorithm: util.HashAlgorithmSHA256, Sum: decodeString("ca94eb1e9d37b8566b45e7c0bd91ebf320fbea7729ae1f2f3f2d73a9f481aa03")},
+						{Algorithm: util.HashAlgorithmSHA512, Sum: decodeString("9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043")},
+					},
+				},
+			},
+			out: out{err: util.ErrHashMismatch{
+				Algorithm:  "sha512",
+				Calculated: "58b63cd659fbf2264a4d59e6061bff888c54d0d98dc27a3167a607ce92076e906352f73bce72d563d66dd5322496c7f9542c0d22bb23955d17e4a71784d1155f",
+				Expected:   "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043",
+			}},
+		},
 		{
 			in: in{
 				URL: url.URL{
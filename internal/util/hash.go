@@ -0,0 +1,131 @@
+// Copyright 2019 Red Hat, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// HashAlgorithm identifies a digest algorithm a fetched resource can be
+// pinned with.
+type HashAlgorithm string
+
+const (
+	HashAlgorithmSHA256     HashAlgorithm = "sha256"
+	HashAlgorithmSHA512     HashAlgorithm = "sha512"
+	HashAlgorithmBlake2b512 HashAlgorithm = "blake2b-512"
+)
+
+// ExpectedHash pins one algorithm/digest pair that a fetched resource must
+// match. A resource can be pinned with more than one of these at once, in
+// which case every one of them must verify.
+type ExpectedHash struct {
+	Algorithm HashAlgorithm
+	Sum       []byte
+}
+
+// NewHash returns a fresh hash.Hash for the given algorithm.
+func NewHash(algorithm HashAlgorithm) (hash.Hash, error) {
+	switch algorithm {
+	case HashAlgorithmSHA256:
+		return sha256.New(), nil
+	case HashAlgorithmSHA512:
+		return sha512.New(), nil
+	case HashAlgorithmBlake2b512:
+		return blake2b.New512(nil)
+	default:
+		return nil, fmt.Errorf("unrecognized hash algorithm %q", algorithm)
+	}
+}
+
+// ErrHashMismatch is returned when a fetched resource's digest doesn't match
+// the one it was pinned with. Algorithm is left empty when the resource was
+// pinned with a single, unnamed digest rather than an ExpectedHash.
+type ErrHashMismatch struct {
+	Algorithm  string
+	Calculated string
+	Expected   string
+}
+
+func (e ErrHashMismatch) Error() string {
+	if e.Algorithm == "" {
+		return fmt.Sprintf("content hash does not match specified hash: calculated %s but expected %s", e.Calculated, e.Expected)
+	}
+	return fmt.Sprintf("%s hash does not match specified hash: calculated %s but expected %s", e.Algorithm, e.Calculated, e.Expected)
+}
+
+// MultiHash fans the bytes written through it out to every configured
+// hash.Hash in a single pass, so a resource pinned with several algorithms
+// doesn't need to be read more than once to verify all of them.
+type MultiHash struct {
+	entries []multiHashEntry
+}
+
+type multiHashEntry struct {
+	algorithm HashAlgorithm
+	hash      hash.Hash
+	expected  []byte
+}
+
+// NewMultiHash builds a MultiHash that will verify every algorithm/digest
+// pair in expected once Verify is called.
+func NewMultiHash(expected []ExpectedHash) (*MultiHash, error) {
+	mh := &MultiHash{entries: make([]multiHashEntry, 0, len(expected))}
+	for _, e := range expected {
+		h, err := NewHash(e.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		mh.entries = append(mh.entries, multiHashEntry{
+			algorithm: e.Algorithm,
+			hash:      h,
+			expected:  e.Sum,
+		})
+	}
+	return mh, nil
+}
+
+// Write implements io.Writer, feeding p to every configured hasher.
+// hash.Hash.Write never returns an error, so neither does this.
+func (m *MultiHash) Write(p []byte) (int, error) {
+	for _, e := range m.entries {
+		e.hash.Write(p)
+	}
+	return len(p), nil
+}
+
+// Verify compares every configured digest against the bytes written so far,
+// returning an ErrHashMismatch naming the first algorithm that doesn't
+// match. It returns nil once every digest has been checked and matches.
+func (m *MultiHash) Verify() error {
+	for _, e := range m.entries {
+		calculated := e.hash.Sum(nil)
+		if !bytes.Equal(calculated, e.expected) {
+			return ErrHashMismatch{
+				Algorithm:  string(e.algorithm),
+				Calculated: hex.EncodeToString(calculated),
+				Expected:   hex.EncodeToString(e.expected),
+			}
+		}
+	}
+	return nil
+}